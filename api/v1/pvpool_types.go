@@ -0,0 +1,358 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PodTemplateUpdateStrategyType controls how the operator rolls out changes
+// to the generated pod template once the StatefulSet already exists.
+// +kubebuilder:validation:Enum=RollingUpdate;OnDelete
+type PodTemplateUpdateStrategyType string
+
+const (
+	// UpdateStrategyRollingUpdate lets the StatefulSet controller replace
+	// pods automatically as the pod template drifts from the desired state.
+	UpdateStrategyRollingUpdate PodTemplateUpdateStrategyType = "RollingUpdate"
+	// UpdateStrategyOnDelete only replaces a pod once it is deleted manually,
+	// for pools where the operator must not disrupt running storage agents.
+	UpdateStrategyOnDelete PodTemplateUpdateStrategyType = "OnDelete"
+)
+
+// AgentProtocol selects the transport used to talk to the storage-agent
+// sidecar running in every PV pool pod.
+// +kubebuilder:validation:Enum=http;grpc
+type AgentProtocol string
+
+const (
+	// AgentProtocolHTTP polls the storage agent's REST API. This is the default.
+	AgentProtocolHTTP AgentProtocol = "http"
+	// AgentProtocolGRPC streams status from the storage agent over gRPC.
+	AgentProtocolGRPC AgentProtocol = "grpc"
+)
+
+// AgentTLSConfig configures mTLS between the operator and the storage agent.
+type AgentTLSConfig struct {
+	// SecretRef names a Secret in the PvPool's namespace holding ca.crt,
+	// tls.crt and tls.key used to dial the storage agent over mTLS.
+	SecretRef corev1.LocalObjectReference `json:"secretRef"`
+}
+
+// DeletePolicy controls what happens to the pool's PVs/PVCs when the PvPool
+// CR itself is deleted, instead of relying purely on owner-ref GC.
+// +kubebuilder:validation:Enum=WipeAndDelete;Retain;Orphan
+type DeletePolicy string
+
+const (
+	// DeletePolicyWipeAndDelete scrubs every PVC (same flow as a scale-down
+	// to zero) before letting the PvPool and its StatefulSet/Service go.
+	// This is the default, matching the pre-existing owner-ref-GC behavior
+	// but without leaking unscrubbed data.
+	DeletePolicyWipeAndDelete DeletePolicy = "WipeAndDelete"
+	// DeletePolicyRetain patches every bound PV's reclaim policy to Retain
+	// and records its metadata in PvPoolStatus.RetainedVolumes so the data
+	// survives the PvPool's deletion and can be rebuilt with PvPoolRestore.
+	DeletePolicyRetain DeletePolicy = "Retain"
+	// DeletePolicyOrphan drops owner references from the Service and
+	// StatefulSet so deleting the PvPool leaves them (and their PVCs/PVs)
+	// running untouched.
+	DeletePolicyOrphan DeletePolicy = "Orphan"
+)
+
+// RetainedVolume records everything PvPoolRestore needs to rebuild a PvPool
+// bound to a PV that was preserved by a DeletePolicyRetain deletion.
+type RetainedVolume struct {
+	// Ordinal is the StatefulSet ordinal the volume was mounted at
+	Ordinal int32 `json:"ordinal"`
+	// PVName is the retained PersistentVolume's name
+	PVName string `json:"pvName"`
+	// StorageClassName the volume was provisioned with
+	// +optional
+	StorageClassName string `json:"storageClassName,omitempty"`
+	// Capacity is the volume's storage capacity
+	Capacity resource.Quantity `json:"capacity"`
+	// AccessModes the volume was bound with
+	AccessModes []corev1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
+	// NodeAffinity constrains which nodes can mount the volume, carried over
+	// from the original PV so local/topology-bound volumes restore correctly.
+	// +optional
+	NodeAffinity *corev1.VolumeNodeAffinity `json:"nodeAffinity,omitempty"`
+}
+
+// PVReclaimPolicy controls what happens to the PersistentVolumes backing
+// PVCs that are orphaned when the pool is scaled down.
+// +kubebuilder:validation:Enum=Delete;Retain
+type PVReclaimPolicy string
+
+const (
+	// PVReclaimPolicyDelete deletes the orphaned PV (and its underlying storage)
+	// once its PVC has been scrubbed and removed.
+	PVReclaimPolicyDelete PVReclaimPolicy = "Delete"
+	// PVReclaimPolicyRetain leaves the orphaned PV in place after its PVC is removed.
+	PVReclaimPolicyRetain PVReclaimPolicy = "Retain"
+)
+
+// PvPoolSpec defines the desired state of PvPool
+type PvPoolSpec struct {
+	// NumPVs is the desired number of PVs (and backing storage-agent pods) in the pool
+	NumPVs int32 `json:"numPVs"`
+
+	// PvSizeGB is the size in GB requested for every PV in the pool
+	PvSizeGB int32 `json:"pvSizeGB"`
+
+	// Image is the storage-agent container image
+	Image string `json:"image"`
+
+	// ScrubberImage is the image used for the short-lived Pod that wipes the
+	// contents of orphaned PVCs before they are deleted. Defaults to Image
+	// when empty.
+	// +optional
+	ScrubberImage string `json:"scrubberImage,omitempty"`
+
+	// ReclaimPolicy controls whether PVs backing orphaned PVCs are deleted or
+	// retained once scrubbing completes. Defaults to Delete.
+	// +optional
+	// +kubebuilder:default=Delete
+	ReclaimPolicy PVReclaimPolicy `json:"reclaimPolicy,omitempty"`
+
+	// Resources overrides the default 100m CPU / 100M memory request/limit
+	// applied to the storage-agent container.
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// ExtraEnvs are additional environment variables merged into the
+	// storage-agent container, alongside the built-in PV_PATH.
+	// +optional
+	ExtraEnvs []corev1.EnvVar `json:"extraEnvs,omitempty"`
+
+	// ExtraEnvFrom are additional EnvFromSources merged into the
+	// storage-agent container, e.g. to inject credentials or TLS material.
+	// +optional
+	ExtraEnvFrom []corev1.EnvFromSource `json:"extraEnvFrom,omitempty"`
+
+	// NodeSelector constrains which nodes the storage-agent pods are
+	// scheduled to.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations allow the storage-agent pods to schedule onto tainted
+	// storage nodes.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Affinity constrains pod placement, e.g. to spread the pool across
+	// failure domains.
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// PriorityClassName is the PriorityClass assigned to the storage-agent pods.
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// ServiceAccountName is the ServiceAccount the storage-agent pods run as.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// Sidecars are additional containers added to every storage-agent pod.
+	// +optional
+	Sidecars []corev1.Container `json:"sidecars,omitempty"`
+
+	// ExtraVolumes are additional volumes added to the pod template, for use
+	// by Sidecars or ExtraVolumeMounts.
+	// +optional
+	ExtraVolumes []corev1.Volume `json:"extraVolumes,omitempty"`
+
+	// ExtraVolumeMounts are additional volume mounts added to the
+	// storage-agent container.
+	// +optional
+	ExtraVolumeMounts []corev1.VolumeMount `json:"extraVolumeMounts,omitempty"`
+
+	// StorageClassName is the StorageClass requested for every PV in the pool.
+	// +optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+
+	// PodAnnotations are merged into the annotations of every storage-agent pod.
+	// +optional
+	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
+
+	// PodLabels are merged into the labels of every storage-agent pod,
+	// alongside the labels the operator uses for its own selector.
+	// +optional
+	PodLabels map[string]string `json:"podLabels,omitempty"`
+
+	// UpdateStrategy controls how pod template changes are rolled out once
+	// the StatefulSet already exists. Defaults to RollingUpdate.
+	// +optional
+	// +kubebuilder:default=RollingUpdate
+	UpdateStrategy PodTemplateUpdateStrategyType `json:"updateStrategy,omitempty"`
+
+	// AgentProtocol selects the transport used to query/drive the storage
+	// agent. Defaults to http.
+	// +optional
+	// +kubebuilder:default=http
+	AgentProtocol AgentProtocol `json:"agentProtocol,omitempty"`
+
+	// TLS configures mTLS between the operator and the storage agent, for
+	// both the http and grpc transports.
+	// +optional
+	TLS *AgentTLSConfig `json:"tls,omitempty"`
+
+	// DeletePolicy controls what happens to the pool's PVs when the PvPool
+	// CR itself is deleted. Defaults to WipeAndDelete.
+	// +optional
+	// +kubebuilder:default=WipeAndDelete
+	DeletePolicy DeletePolicy `json:"deletePolicy,omitempty"`
+}
+
+// DecommissionJobPhase is the phase of a single PVC scrub/decommission job
+type DecommissionJobPhase string
+
+const (
+	// DecommissionJobPhasePending means the storage agent was told to
+	// decommission but has not finished draining, or the scrubber pod has
+	// been created but is not running yet
+	DecommissionJobPhasePending DecommissionJobPhase = "Pending"
+	// DecommissionJobPhaseDraining means the storage agent confirmed it
+	// drained, but the StatefulSet pod is still bound to the PVC; the pool
+	// must be scaled down to release it before the scrubber pod can be created
+	DecommissionJobPhaseDraining DecommissionJobPhase = "Draining"
+	// DecommissionJobPhaseScrubbing means the scrubber pod is running
+	DecommissionJobPhaseScrubbing DecommissionJobPhase = "Scrubbing"
+	// DecommissionJobPhaseSucceeded means the scrubber pod completed and the PVC can be deleted
+	DecommissionJobPhaseSucceeded DecommissionJobPhase = "Succeeded"
+	// DecommissionJobPhaseFailed means the scrubber pod failed and will be retried
+	DecommissionJobPhaseFailed DecommissionJobPhase = "Failed"
+	// DecommissionJobPhaseDone means the PVC (and PV, if applicable) have been removed
+	DecommissionJobPhaseDone DecommissionJobPhase = "Done"
+)
+
+// DecommissionJob tracks the cleanup of a single orphaned PVC left behind by
+// scaling the pool down, so the reconciler can resume/retry idempotently.
+type DecommissionJob struct {
+	// Ordinal is the StatefulSet ordinal of the orphaned volume (vol-<sts>-<n>)
+	Ordinal int32 `json:"ordinal"`
+	// PodName is the storage-agent pod being drained while the StatefulSet
+	// pod still exists, and the scrubber Pod handling this PVC after that
+	PodName string `json:"podName"`
+	// Phase is the current phase of the scrub job
+	Phase DecommissionJobPhase `json:"phase"`
+	// StartedAt is when the reconciler first recorded a job for this ordinal
+	StartedAt v1.Time `json:"startedAt"`
+}
+
+// PvPoolPhase represent the phase of the PvPool
+type PvPoolPhase string
+
+const (
+	// PvPoolPhaseUnknown means the phase was not yet determined
+	PvPoolPhaseUnknown PvPoolPhase = "Unknown"
+	// PvPoolPhaseScaling means the pool is converging on the desired number of PVs
+	PvPoolPhaseScaling PvPoolPhase = "Scaling"
+	// PvPoolPhaseReady means the pool converged and all PVs are ready
+	PvPoolPhaseReady PvPoolPhase = "Ready"
+)
+
+// PvPodStatus represents the status of a single storage-agent pod as reported
+// by the storage agent itself
+type PvPodStatus string
+
+const (
+	// PvPodStatusUnknown means the storage agent status could not be determined
+	PvPodStatusUnknown PvPodStatus = "Unknown"
+	// PvPodStatusReady means the storage agent is serving
+	PvPodStatusReady PvPodStatus = "Ready"
+	// PvPodStatusDecommissioning means the storage agent is draining
+	PvPodStatusDecommissioning PvPodStatus = "Decommissioning"
+	// PvPodStatusDecommissioned means the storage agent finished draining and
+	// its PVC is ready to be scrubbed and removed
+	PvPodStatusDecommissioned PvPodStatus = "Decommissioned"
+)
+
+// PvPodSInfo holds the reported status of a single PV pool pod
+type PvPodSInfo struct {
+	// PodName is the name of the storage-agent pod
+	PodName string `json:"podName"`
+	// PodStatus is the last known status reported by the storage agent
+	PodStatus PvPodStatus `json:"podStatus"`
+}
+
+// PvPoolStatus defines the observed state of PvPool
+type PvPoolStatus struct {
+	// Phase is the overall phase of the pool
+	Phase PvPoolPhase `json:"phase,omitempty"`
+
+	// PodsInfo holds the last known status of every pod in the pool
+	// +optional
+	PodsInfo []PvPodSInfo `json:"podsInfo,omitempty"`
+
+	// CountByState counts the pods in the pool by their reported status
+	// +optional
+	CountByState map[PvPodStatus]int32 `json:"countByState,omitempty"`
+
+	// Used is the storage usage of the pool in percents
+	// +optional
+	Used int `json:"used,omitempty"`
+
+	// ServiceName is the actual name of the Service backing this pool, which
+	// may be a truncated/hashed derivative of the PvPool's name
+	// +optional
+	ServiceName string `json:"serviceName,omitempty"`
+
+	// StatefulSetName is the actual name of the StatefulSet backing this
+	// pool, which may be a truncated/hashed derivative of the PvPool's name
+	// +optional
+	StatefulSetName string `json:"statefulSetName,omitempty"`
+
+	// DecommissionJobs tracks the cleanup of orphaned PVCs left behind by
+	// scaling the pool down, keyed by ordinal so reconciles are idempotent.
+	// +optional
+	DecommissionJobs []DecommissionJob `json:"decommissionJobs,omitempty"`
+
+	// RetainedVolumes records the PVs preserved by a DeletePolicyRetain
+	// deletion, so a PvPoolRestore can rebuild a pool bound to them.
+	// +optional
+	RetainedVolumes []RetainedVolume `json:"retainedVolumes,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+
+// PvPool is the Schema for the pvpools API
+type PvPool struct {
+	v1.TypeMeta   `json:",inline"`
+	v1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PvPoolSpec   `json:"spec,omitempty"`
+	Status PvPoolStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PvPoolList contains a list of PvPool
+type PvPoolList struct {
+	v1.TypeMeta `json:",inline"`
+	v1.ListMeta `json:"metadata,omitempty"`
+	Items       []PvPool `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PvPool{}, &PvPoolList{})
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PvPoolRestoreSpec defines a PvPool to recreate bound to PVs retained by a
+// prior PvPool deletion (DeletePolicyRetain). The caller copies the
+// RetainedVolumes they want to recover out of the deleted PvPool's last
+// PvPoolStatus.
+type PvPoolRestoreSpec struct {
+	// PvPoolName is the name of the PvPool to create, bound to the retained volumes.
+	PvPoolName string `json:"pvPoolName"`
+
+	// PvPoolTemplate is the spec used to create the new PvPool. NumPVs is
+	// overridden to len(RetainedVolumes) regardless of what is set here.
+	PvPoolTemplate PvPoolSpec `json:"pvPoolTemplate"`
+
+	// RetainedVolumes are the volumes to rebind, normally copied verbatim
+	// from the deleted PvPool's Status.RetainedVolumes.
+	RetainedVolumes []RetainedVolume `json:"retainedVolumes"`
+}
+
+// PvPoolRestorePhase is the phase of a PvPoolRestore
+type PvPoolRestorePhase string
+
+const (
+	// PvPoolRestorePhasePending means rebinding has not started yet
+	PvPoolRestorePhasePending PvPoolRestorePhase = "Pending"
+	// PvPoolRestorePhaseBinding means the PVs are being made claimable again
+	PvPoolRestorePhaseBinding PvPoolRestorePhase = "Binding"
+	// PvPoolRestorePhaseComplete means the PvPool was created and bound to every retained volume
+	PvPoolRestorePhaseComplete PvPoolRestorePhase = "Complete"
+)
+
+// PvPoolRestoreStatus defines the observed state of PvPoolRestore
+type PvPoolRestoreStatus struct {
+	// Phase is the phase of the restore operation
+	// +optional
+	Phase PvPoolRestorePhase `json:"phase,omitempty"`
+
+	// BoundVolumes counts how many retained volumes have been made
+	// claimable again for the new PvPool
+	// +optional
+	BoundVolumes int32 `json:"boundVolumes,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+
+// PvPoolRestore is the Schema for the pvpoolrestores API
+type PvPoolRestore struct {
+	v1.TypeMeta   `json:",inline"`
+	v1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PvPoolRestoreSpec   `json:"spec,omitempty"`
+	Status PvPoolRestoreStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PvPoolRestoreList contains a list of PvPoolRestore
+type PvPoolRestoreList struct {
+	v1.TypeMeta `json:",inline"`
+	v1.ListMeta `json:"metadata,omitempty"`
+	Items       []PvPoolRestore `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PvPoolRestore{}, &PvPoolRestoreList{})
+}
@@ -19,9 +19,6 @@ package controllers
 import (
 	"context"
 	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"net/http"
 	"reflect"
 	"time"
 	"strconv"
@@ -40,20 +37,31 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	pvpoolv1 "github.com/noobaa/pv-pool-operator/api/v1"
+	"github.com/noobaa/pv-pool-operator/pkg/naming"
 )
 
 const (
 	dataMountPath    = "/data"
 	storageAgentPort = 8080
-)
 
-// storageAgentStatus is the status returned by storage agent
-type storageAgentStatus struct {
-	Name  string `json:"name"`
-	Total int64  `json:"total"`
-	Used  int64  `json:"used"`
-	State string `json:"state"`
-}
+	// annotationServiceName and annotationStatefulSetName pin the derived
+	// child object names on the PvPool so upgrades never recompute a
+	// different truncated/hashed name out from under an existing Service
+	// or StatefulSet.
+	annotationServiceName     = "pvpool.noobaa.com/service-name"
+	annotationStatefulSetName = "pvpool.noobaa.com/statefulset-name"
+
+	// labelPoolHash is the selector label used instead of the raw PvPool
+	// name, which may contain characters or exceed lengths label values
+	// and selectors reject.
+	labelPoolHash = "pv-pool-hash"
+
+	// annotationTemplateHash records a hash of the pod template/update
+	// strategy we last wrote, so drift detection compares against what we
+	// actually set rather than against the API server's defaulted live
+	// object, which would never equal the sparse desired spec.
+	annotationTemplateHash = "pvpool.noobaa.com/template-hash"
+)
 
 // PvPoolReconciler reconciles a PvPool object
 type PvPoolReconciler struct {
@@ -80,7 +88,10 @@ func requeueWithError(err error) (ctrl.Result, error) {
 // +kubebuilder:rbac:groups=pvpool.noobaa.com,resources=pvpools/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=pvpool.noobaa.com,resources=pvpools/finalizers,verbs=update
 // +kubebuilder:rbac:groups="",resources=services,verbs=*
-// +kubebuilder:rbac:groups="",resources=pods,verbs=*
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;delete
+// +kubebuilder:rbac:groups="",resources=persistentvolumes,verbs=get;list;watch;update;delete
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
 // +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=*
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
@@ -113,7 +124,27 @@ func (r *PvPoolReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		return requeueWithError(err)
 	}
 
+	if pvPool.DeletionTimestamp != nil {
+		return r.finalizePvPool(ctx, pvPool)
+	}
+
+	needsUpdate := !containsString(pvPool.Finalizers, pvPoolFinalizer)
+	if needsUpdate {
+		pvPool.Finalizers = append(pvPool.Finalizers, pvPoolFinalizer)
+	}
+	if r.ensurePvPoolNames(pvPool) {
+		needsUpdate = true
+	}
+	if needsUpdate {
+		if err := r.Update(ctx, pvPool); err != nil {
+			r.Log.Error(err, "Failed to register finalizer/child names on PvPool")
+			return requeueWithError(err)
+		}
+	}
+
 	newStatus, reconcileErr := r.reconcilePvPool(pvPool)
+	newStatus.ServiceName = r.getPvPoolServiceName(pvPool)
+	newStatus.StatefulSetName = r.getPvPoolStatefulsetName(pvPool)
 
 	// Update status if needed
 	if !reflect.DeepEqual(newStatus, pvPool.Status) {
@@ -170,7 +201,7 @@ func (r *PvPoolReconciler) reconcilePvPool(pvp *pvpoolv1.PvPool) (*pvpoolv1.PvPo
 		return newStatus, err
 	}
 
-	err = r.collectPodsStatus(newStatus, podList)
+	err = r.collectPodsStatus(pvp, newStatus, podList)
 	if err != nil {
 		r.Log.Error(err, "Failed to get storage agents status")
 		return newStatus, err
@@ -186,9 +217,32 @@ func (r *PvPoolReconciler) reconcilePvPool(pvp *pvpoolv1.PvPool) (*pvpoolv1.PvPo
 
 }
 
+// ensurePvPoolNames assigns the Service/StatefulSet names derived from the
+// PvPool's name into its annotations, if they are not already set, so the
+// names stay stable across upgrades even if the hashing scheme changes.
+// Returns true if the PvPool object was mutated and needs to be persisted.
+func (r *PvPoolReconciler) ensurePvPoolNames(pvp *pvpoolv1.PvPool) bool {
+	changed := false
+	if pvp.Annotations == nil {
+		pvp.Annotations = map[string]string{}
+	}
+	if pvp.Annotations[annotationServiceName] == "" {
+		pvp.Annotations[annotationServiceName] = naming.ChildName(pvp.Name, "-srv")
+		changed = true
+	}
+	if pvp.Annotations[annotationStatefulSetName] == "" {
+		pvp.Annotations[annotationStatefulSetName] = naming.ChildName(pvp.Name, "-sts")
+		changed = true
+	}
+	return changed
+}
+
 // returns the conventional service name for the reconciled PV pool
 func (r *PvPoolReconciler) getPvPoolServiceName(pvp *pvpoolv1.PvPool) string {
-	return pvp.Name + "-srv"
+	if name := pvp.Annotations[annotationServiceName]; name != "" {
+		return name
+	}
+	return naming.ChildName(pvp.Name, "-srv")
 }
 
 func (r *PvPoolReconciler) ensurePvPoolService(pvp *pvpoolv1.PvPool) (*corev1.Service, error) {
@@ -222,7 +276,7 @@ func (r *PvPoolReconciler) ensurePvPoolService(pvp *pvpoolv1.PvPool) (*corev1.Se
 
 func (r *PvPoolReconciler) getPvPoolLabels(pvp *pvpoolv1.PvPool) map[string]string {
 	return map[string]string{
-		"pv-pool": pvp.Name,
+		labelPoolHash: naming.LabelHash(pvp.Name),
 	}
 }
 
@@ -248,7 +302,10 @@ func (r *PvPoolReconciler) newServiceForPvPool(pvp *pvpoolv1.PvPool) *corev1.Ser
 
 // returns the conventional statefulset name for the reconciled PV pool
 func (r *PvPoolReconciler) getPvPoolStatefulsetName(pvp *pvpoolv1.PvPool) string {
-	return pvp.Name + "-sts"
+	if name := pvp.Annotations[annotationStatefulSetName]; name != "" {
+		return name
+	}
+	return naming.ChildName(pvp.Name, "-sts")
 }
 
 func (r *PvPoolReconciler) ensurePvPoolStatefulset(pvp *pvpoolv1.PvPool) (*appsv1.StatefulSet, error) {
@@ -285,56 +342,116 @@ func (r *PvPoolReconciler) ensurePvPoolStatefulset(pvp *pvpoolv1.PvPool) (*appsv
 
 }
 
+// newPodSpecForPvPool builds the storage-agent PodSpec, merging in every
+// pluggable field the PvPool spec allows so production clusters can run the
+// pool on tainted storage nodes, with sidecars, and with injected
+// credentials/TLS material.
+func (r *PvPoolReconciler) newPodSpecForPvPool(pvp *pvpoolv1.PvPool) corev1.PodSpec {
+	// resources limits requests. no need for higher values, to allow all pods to start on weak clusters
+	resourcesReq := corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    *resource.NewScaledQuantity(int64(100), resource.Milli),
+			corev1.ResourceMemory: *resource.NewScaledQuantity(int64(100), resource.Mega),
+		},
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    *resource.NewScaledQuantity(int64(100), resource.Milli),
+			corev1.ResourceMemory: *resource.NewScaledQuantity(int64(100), resource.Mega),
+		},
+	}
+	if pvp.Spec.Resources != nil {
+		resourcesReq = *pvp.Spec.Resources
+	}
+
+	envs := append([]corev1.EnvVar{
+		{
+			Name:  "PV_PATH",
+			Value: dataMountPath,
+		},
+	}, pvp.Spec.ExtraEnvs...)
+
+	volumeMounts := append([]corev1.VolumeMount{
+		{
+			Name:      "vol",
+			MountPath: dataMountPath,
+		},
+	}, pvp.Spec.ExtraVolumeMounts...)
+
+	containers := append([]corev1.Container{
+		{
+			Name:         "storage-agent",
+			Image:        pvp.Spec.Image,
+			Env:          envs,
+			EnvFrom:      pvp.Spec.ExtraEnvFrom,
+			Command:      []string{"node", "storage-agent.js"},
+			Resources:    resourcesReq,
+			VolumeMounts: volumeMounts,
+		},
+	}, pvp.Spec.Sidecars...)
+
+	return corev1.PodSpec{
+		Containers:         containers,
+		Volumes:            pvp.Spec.ExtraVolumes,
+		NodeSelector:       pvp.Spec.NodeSelector,
+		Tolerations:        pvp.Spec.Tolerations,
+		Affinity:           pvp.Spec.Affinity,
+		PriorityClassName:  pvp.Spec.PriorityClassName,
+		ServiceAccountName: pvp.Spec.ServiceAccountName,
+	}
+}
+
+// mergeStringMaps returns a new map containing the entries of base
+// overridden/extended by extra.
+func mergeStringMaps(base, extra map[string]string) map[string]string {
+	merged := make(map[string]string, len(base)+len(extra))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	return merged
+}
+
+// templateHash returns a deterministic hash of the fields we manage on the
+// StatefulSet's pod template and update strategy, used to detect drift
+// without comparing against API-server-defaulted fields we don't own.
+func templateHash(template corev1.PodTemplateSpec, updateStrategy appsv1.StatefulSetUpdateStrategy) string {
+	data, _ := json.Marshal(struct {
+		Template       corev1.PodTemplateSpec
+		UpdateStrategy appsv1.StatefulSetUpdateStrategy
+	}{template, updateStrategy})
+	return naming.FNV32aHex(string(data))
+}
+
 func (r *PvPoolReconciler) newStatefulsetForPvPool(pvp *pvpoolv1.PvPool) *appsv1.StatefulSet {
 	pvPoolSTS := &appsv1.StatefulSet{}
 	pvPoolSTS.Name = r.getPvPoolStatefulsetName(pvp)
 	pvPoolSTS.Namespace = pvp.Namespace
 	replicas := pvp.Spec.NumPVs
 
-	// resources limits requests. no need for higher values, to allow all pods to start on weak clusters
-	resourcesReq := corev1.ResourceList{
-		corev1.ResourceCPU:    *resource.NewScaledQuantity(int64(100), resource.Milli),
-		corev1.ResourceMemory: *resource.NewScaledQuantity(int64(100), resource.Mega),
-	}
-
 	// convert the requested PV size to bytes
 	pvSizeBytes := int64(pvp.Spec.PvSizeGB) * 1024 * 1024 * 1024
 
+	podLabels := mergeStringMaps(r.getPvPoolLabels(pvp), pvp.Spec.PodLabels)
+
+	updateStrategy := appsv1.StatefulSetUpdateStrategy{Type: appsv1.RollingUpdateStatefulSetStrategyType}
+	if pvp.Spec.UpdateStrategy == pvpoolv1.UpdateStrategyOnDelete {
+		updateStrategy = appsv1.StatefulSetUpdateStrategy{Type: appsv1.OnDeleteStatefulSetStrategyType}
+	}
+
 	pvPoolSTS.Spec = appsv1.StatefulSetSpec{
 		Replicas: &replicas,
 		Selector: &v1.LabelSelector{
 			MatchLabels: r.getPvPoolLabels(pvp),
 		},
-		ServiceName: r.getPvPoolServiceName(pvp),
+		ServiceName:    r.getPvPoolServiceName(pvp),
+		UpdateStrategy: updateStrategy,
 		Template: corev1.PodTemplateSpec{
 			ObjectMeta: v1.ObjectMeta{
-				Labels: r.getPvPoolLabels(pvp),
-			},
-			Spec: corev1.PodSpec{
-				Containers: []corev1.Container{
-					{
-						Name:  "storage-agent",
-						Image: pvp.Spec.Image,
-						Env: []corev1.EnvVar{
-							{
-								Name:  "PV_PATH",
-								Value: dataMountPath,
-							},
-						},
-						Command: []string{"node", "storage-agent.js"},
-						Resources: corev1.ResourceRequirements{
-							Limits:   resourcesReq,
-							Requests: resourcesReq,
-						},
-						VolumeMounts: []corev1.VolumeMount{
-							{
-								Name:      "vol",
-								MountPath: dataMountPath,
-							},
-						},
-					},
-				},
+				Labels:      podLabels,
+				Annotations: pvp.Spec.PodAnnotations,
 			},
+			Spec: r.newPodSpecForPvPool(pvp),
 		},
 		VolumeClaimTemplates: []corev1.PersistentVolumeClaim{
 			{
@@ -343,6 +460,7 @@ func (r *PvPoolReconciler) newStatefulsetForPvPool(pvp *pvpoolv1.PvPool) *appsv1
 					AccessModes: []corev1.PersistentVolumeAccessMode{
 						corev1.ReadWriteOnce,
 					},
+					StorageClassName: pvp.Spec.StorageClassName,
 					Resources: corev1.ResourceRequirements{
 						Requests: corev1.ResourceList{
 							corev1.ResourceStorage: *resource.NewQuantity(pvSizeBytes, resource.BinarySI),
@@ -353,6 +471,10 @@ func (r *PvPoolReconciler) newStatefulsetForPvPool(pvp *pvpoolv1.PvPool) *appsv1
 		},
 	}
 
+	pvPoolSTS.Annotations = map[string]string{
+		annotationTemplateHash: templateHash(pvPoolSTS.Spec.Template, pvPoolSTS.Spec.UpdateStrategy),
+	}
+
 	// set this pvpool resources as the statefulset owner
 	ctrl.SetControllerReference(pvp, pvPoolSTS, r.Scheme)
 
@@ -363,6 +485,17 @@ func (r *PvPoolReconciler) reconcilePvPoolStatefulset(pvp *pvpoolv1.PvPool, sts
 
 	r.Log.Info("reconcilePvPoolStatefulset ", "statefulset name", sts.Name)
 	
+	// decommissionRequiredPods is driven by what actually still exists (live
+	// pods and leftover PVCs) rather than by the current replica count, so it
+	// must run on every reconcile - including once replicas already match
+	// NumPVs - or an orphaned PVC left behind by an earlier scale-down would
+	// never get scrubbed, and its progress would never reach PvPoolStatus.
+	jobs, err := r.decommissionRequiredPods(pvp, sts, list)
+	newStatus.DecommissionJobs = jobs
+	if err != nil {
+		return err
+	}
+
 	// the statefulset exists. reconcile the properties in the PV pool CR
 	shouldUpdate := false
 	if pvp.Spec.NumPVs >= *sts.Spec.Replicas && newStatus.CountByState[pvpoolv1.PvPodStatusReady] != pvp.Spec.NumPVs {
@@ -376,7 +509,31 @@ func (r *PvPoolReconciler) reconcilePvPoolStatefulset(pvp *pvpoolv1.PvPool, sts
 	} else if pvp.Spec.NumPVs < *sts.Spec.Replicas {
 		// Start scaling down
 		r.Log.Info("Start scaling down ", "statefulset name", sts.Name)
-		r.decommissionRequiredPods(pvp, sts, list)
+		newStatus.Phase = pvpoolv1.PvPoolPhaseScaling
+
+		// shrink the StatefulSet just enough to terminate every orphaned pod
+		// whose storage agent has confirmed it drained (or whose PVC is
+		// already reclaimed), highest ordinal first. The workload pod must
+		// release the PVC this way *before* the scrubber tries to mount it,
+		// otherwise the two contend over the same ReadWriteOnce volume.
+		target := *sts.Spec.Replicas
+		for target > pvp.Spec.NumPVs {
+			job := jobForOrdinal(jobs, target-1)
+			if job == nil || (job.Phase != pvpoolv1.DecommissionJobPhaseDraining && job.Phase != pvpoolv1.DecommissionJobPhaseDone) {
+				break
+			}
+			target--
+		}
+		if target != *sts.Spec.Replicas {
+			shouldUpdate = true
+			sts.Spec.Replicas = &target
+		}
+
+	} else if len(jobs) > 0 {
+		// replicas already match NumPVs, but an earlier scale-down left
+		// orphaned PVCs still being scrubbed - stay in Scaling until
+		// decommissionRequiredPods reports them all reclaimed.
+		newStatus.Phase = pvpoolv1.PvPoolPhaseScaling
 
 	} else if newStatus.CountByState[pvpoolv1.PvPodStatusReady] == pvp.Spec.NumPVs {
 		// in this case the sts is reconciled (numPvs == sts.Spec.Replicas) and all pods are ready
@@ -387,6 +544,25 @@ func (r *PvPoolReconciler) reconcilePvPoolStatefulset(pvp *pvpoolv1.PvPool, sts
 		newStatus.Phase = pvpoolv1.PvPoolPhaseScaling
 	}
 
+	// the pod template (resources, envs, sidecars, affinity, ...) can drift
+	// from the desired spec even when replicas already match, e.g. after the
+	// user edits the PvPool spec. Compare against the template-hash
+	// annotation we wrote ourselves rather than the live template directly:
+	// the API server defaults fields (ImagePullPolicy, SecurityContext,
+	// DNSPolicy, canonicalized quantities, ...) on the object we read back,
+	// so a raw DeepEqual against our sparse desired template never matches.
+	desired := r.newStatefulsetForPvPool(pvp)
+	if sts.Annotations[annotationTemplateHash] != desired.Annotations[annotationTemplateHash] {
+		r.Log.Info("pod template drifted from desired spec. will patch", "statefulset name", sts.Name)
+		shouldUpdate = true
+		sts.Spec.Template = desired.Spec.Template
+		sts.Spec.UpdateStrategy = desired.Spec.UpdateStrategy
+		if sts.Annotations == nil {
+			sts.Annotations = map[string]string{}
+		}
+		sts.Annotations[annotationTemplateHash] = desired.Annotations[annotationTemplateHash]
+	}
+
 	if shouldUpdate {
 		r.Log.Info("found differences between existing sts and the desired one. will update", "statefulset name", sts.Name)
 		// update the STS
@@ -406,24 +582,25 @@ func (r *PvPoolReconciler) percent(part int64, all int64) int {
 	return int(p)
 }
 
-func (r *PvPoolReconciler) collectPodsStatus(pvpStatus *pvpoolv1.PvPoolStatus, list *corev1.PodList) error {
+func (r *PvPoolReconciler) collectPodsStatus(pvp *pvpoolv1.PvPool, pvpStatus *pvpoolv1.PvPoolStatus, list *corev1.PodList) error {
 
-	for _, pod := range list.Items {
+	client := r.storageAgentClient(pvp)
+	results := r.queryPodsStatusConcurrently(context.TODO(), client, list)
 
-		r.Log.Info("collectPodsStatus", "status", pod.Name)
-		state := pvpoolv1.PvPodStatus(pvpoolv1.PvPodStatusUnknown)
-		agentStatus, err := r.getStorageAgentStatus(r.getPodURL(pod.Name, pod.Spec.Subdomain, pod.Namespace))
-		if err != nil {
-			r.Log.Info("got error when trying to get storage agent status. setting the state to unknown", "pod name", pod.Name, "error", err)
-			return err
-		} else {
-			state = pvpoolv1.PvPodStatus(agentStatus.State)
-			r.Log.Info("pv got agentStatus", "status", agentStatus)
+	for _, result := range results {
+		state := pvpoolv1.PvPodStatusUnknown
+		if result.err != nil {
+			r.Log.Info("got error when trying to get storage agent status. setting the state to unknown", "pod name", result.pod.Name, "error", result.err)
+			return result.err
 		}
-		pvpStatus.PodsInfo = append(pvpStatus.PodsInfo, pvpoolv1.PvPodSInfo{PodName: pod.Name, PodStatus: state})
+
+		state = pvpoolv1.PvPodStatus(result.status.State)
+		r.Log.Info("pv got agentStatus", "status", result.status)
+
+		pvpStatus.PodsInfo = append(pvpStatus.PodsInfo, pvpoolv1.PvPodSInfo{PodName: result.pod.Name, PodStatus: state})
 		pvpStatus.CountByState[state]++
 		//Used storage in percentage.
-		pvpStatus.Used = r.percent(agentStatus.Used, agentStatus.Total)
+		pvpStatus.Used = r.percent(result.status.Used, result.status.Total)
 		r.Log.Info("Used storage in percentage", "Used", pvpStatus.Used)
 	}
 
@@ -439,122 +616,118 @@ func (r *PvPoolReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Complete(r)
 }
 
-func (r *PvPoolReconciler) getPodURL(podName string, serviceName string, namespace string) string {
-	return fmt.Sprintf("http://%s.%s.%s.svc:%d", podName, serviceName, namespace, storageAgentPort)
+func (r *PvPoolReconciler) getInstanceNumberString(p string, s string) (int, error) {
+	numStr := strings.TrimPrefix(p, s + "-")
+	return strconv.Atoi(numStr)
 }
 
-// getStorageAgentStatus makes an http request to the storage agent to query the status
-func (r *PvPoolReconciler) getStorageAgentStatus(url string) (*storageAgentStatus, error) {
-
-	urlRoute := url + "/status"
-
-	agentClient := http.Client{
-		Timeout: time.Second * 2,
-	}
-
-	req, err := http.NewRequest(http.MethodGet, urlRoute, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	res, err := agentClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-
-	if res.StatusCode != 200 {
-		err := fmt.Errorf("storage agent did not retrun the expected status code. got statusCode=%v", res.StatusCode)
-		return nil, err
-	}
-
-	if res.Body != nil {
-		defer res.Body.Close()
-	}
-
-	body, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	status := &storageAgentStatus{}
-	err = json.Unmarshal(body, status)
-	if err != nil {
-		return nil, err
+// jobForOrdinal returns the DecommissionJob for ordinal in jobs, or nil.
+func jobForOrdinal(jobs []pvpoolv1.DecommissionJob, ordinal int32) *pvpoolv1.DecommissionJob {
+	for i := range jobs {
+		if jobs[i].Ordinal == ordinal {
+			return &jobs[i]
+		}
 	}
-
-	return status, nil
-
+	return nil
 }
 
-func (r *PvPoolReconciler) decommissionStorageAgent(url string) error {
-
-	urlRoute := url + "/manage-agent/decommission"
-
-	agentClient := http.Client{
-		Timeout: time.Second * 2,
+// decommissionRequiredPods tells the storage agent of every pod whose
+// ordinal is beyond the desired pool size to decommission. Once an agent
+// confirms it drained, the pod is left running (still bound to its PVC)
+// and reported as Draining so the caller can scale the StatefulSet down
+// and free the PVC; the scrubber only ever runs against an ordinal whose
+// pod has already been terminated that way.
+//
+// Which ordinals to process is driven by what actually still exists (live
+// pods and leftover PVCs), not by ranging over [NumPVs, sts.Spec.Replicas):
+// the moment an ordinal's pod is scaled away, it drops out of that range
+// even though its PVC hasn't been scrubbed yet, which would otherwise leak
+// it forever. An ordinal whose PVC has already been reclaimed simply no
+// longer appears in either set, which is what makes this idempotent across
+// reconciles without needing to consult Status.DecommissionJobs directly.
+// It returns the DecommissionJobs observed so far so callers can persist
+// cleanup progress in PvPoolStatus and retry idempotently.
+func (r *PvPoolReconciler) decommissionRequiredPods(pvp *pvpoolv1.PvPool, sts *appsv1.StatefulSet, list *corev1.PodList) ([]pvpoolv1.DecommissionJob, error) {
+
+	jobs := make([]pvpoolv1.DecommissionJob, 0)
+	storageClient := r.storageAgentClient(pvp)
+	ctx := context.TODO()
+
+	podByOrdinal := make(map[int32]corev1.Pod, len(list.Items))
+	for _, pod := range list.Items {
+		num, err := r.getInstanceNumberString(pod.Name, sts.Name)
+		if err != nil {
+			r.Log.Info("Pod has a wrong instance number", pod.Name, err)
+			continue
+		}
+		podByOrdinal[int32(num)] = pod
 	}
 
-	req, err := http.NewRequest(http.MethodPut, urlRoute, nil)
+	claims, err := r.listPvPoolVolumeClaims(ctx, pvp, sts.Name)
 	if err != nil {
-		return err
+		return jobs, err
 	}
 
-	res, err := agentClient.Do(req)
-	if err != nil {
-		return err
+	orphaned := make(map[int32]struct{}, len(claims))
+	for ordinal := range podByOrdinal {
+		if ordinal >= pvp.Spec.NumPVs {
+			orphaned[ordinal] = struct{}{}
+		}
 	}
-
-	if res.Body != nil {
-		defer res.Body.Close()
+	for ordinal := range claims {
+		if ordinal >= pvp.Spec.NumPVs {
+			orphaned[ordinal] = struct{}{}
+		}
 	}
 
-	return nil
-}
-
-
-func (r *PvPoolReconciler) getInstanceNumberString(p string, s string) (int, error) {
-	numStr := strings.TrimPrefix(p, s + "-")
-	return strconv.Atoi(numStr)
-}
-
-func (r *PvPoolReconciler) decommissionRequiredPods(pvp *pvpoolv1.PvPool, sts *appsv1.StatefulSet,list *corev1.PodList ) error {
-
-	for _, pod := range list.Items {
-		num, err := r.getInstanceNumberString(pod.Name, sts.Name)
-		if err != nil {
-			r.Log.Info("Pod has a wrong instance number", pod.Name, err)
+	for _, ordinal := range sortedOrdinalSet(orphaned) {
+		pod, podExists := podByOrdinal[ordinal]
+		if !podExists {
+			// the StatefulSet already terminated this pod (we scaled it down
+			// once its agent confirmed draining) - the PVC is now free to scrub
+			job, err := r.reclaimOrphanedPVC(pvp, ordinal)
+			if job != nil {
+				jobs = append(jobs, *job)
+			}
+			if err != nil {
+				return jobs, err
+			}
 			continue
 		}
 
-		state := pvpoolv1.PvPodStatus(pvpoolv1.PvPodStatusUnknown)
-		agentStatus, err := r.getStorageAgentStatus(r.getPodURL(pod.Name, pod.Spec.Subdomain, pod.Namespace))
+		agentStatus, err := storageClient.Status(ctx, podFor(pod))
 		if err != nil {
 			r.Log.Info("got error when trying to get storage agent status. setting the state to unknown", "pod name", pod.Name, "error", err)
-			return err
-		} else {
-			state = pvpoolv1.PvPodStatus(agentStatus.State)
-			r.Log.Info("pv got agentStatus", "status", agentStatus)
+			return jobs, err
 		}
-	
-		if int32(num) >= pvp.Spec.NumPVs && state != pvpoolv1.PvPodStatusDecommissioning {
-			url := r.getPodURL(pod.Name, pod.Spec.Subdomain, pod.Namespace)
+		state := pvpoolv1.PvPodStatus(agentStatus.State)
+		r.Log.Info("pv got agentStatus", "status", agentStatus)
+
+		startedAt := startedAtFor(pvp, ordinal)
+
+		if state == pvpoolv1.PvPodStatusDecommissioned {
+			jobs = append(jobs, pvpoolv1.DecommissionJob{
+				Ordinal:   ordinal,
+				PodName:   pod.Name,
+				Phase:     pvpoolv1.DecommissionJobPhaseDraining,
+				StartedAt: startedAt,
+			})
+			continue
+		}
+
+		if state != pvpoolv1.PvPodStatusDecommissioning {
 			r.Log.Info("decommissionRequiredPods", "status", pod.Name)
-			err := r.decommissionStorageAgent(url)
-			if err != nil {
+			if err := storageClient.Decommission(ctx, podFor(pod)); err != nil {
 				r.Log.Info("got error when trying to decommision. setting the state to unknown", "pod name", pod.Name, "error", err)
-				return err
-			}
-
-
-			podsInfo := pvp.Status.PodsInfo
-			for _, pInfo := range podsInfo {
-				req_num, _ := r.getInstanceNumberString(pInfo.PodName, sts.Name)
-				if num == req_num {
-					decomm_state := pvpoolv1.PvPodStatus(pvpoolv1.PvPodStatusDecommissioned)
-					pInfo.PodStatus = decomm_state
-				}
+				return jobs, err
 			}
 		}
-	}
-	return nil
+		jobs = append(jobs, pvpoolv1.DecommissionJob{
+			Ordinal:   ordinal,
+			PodName:   pod.Name,
+			Phase:     pvpoolv1.DecommissionJobPhasePending,
+			StartedAt: startedAt,
+		})
+	}
+	return jobs, nil
 }
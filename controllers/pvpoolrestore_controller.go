@@ -0,0 +1,184 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	pvpoolv1 "github.com/noobaa/pv-pool-operator/api/v1"
+	"github.com/noobaa/pv-pool-operator/pkg/naming"
+)
+
+// PvPoolRestoreReconciler reconciles a PvPoolRestore object
+type PvPoolRestoreReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=pvpool.noobaa.com,resources=pvpoolrestores,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=pvpool.noobaa.com,resources=pvpoolrestores/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=pvpool.noobaa.com,resources=pvpools,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups="",resources=persistentvolumes,verbs=get;list;watch;update;patch
+
+// Reconcile makes every RetainedVolume on a PvPoolRestore claimable again and
+// creates the PvPool that will claim them.
+//
+// For more details, check Reconcile and its Result here:
+// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.7.0/pkg/reconcile
+func (r *PvPoolRestoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+
+	r.Log.Info("Starting reconcile..", "Request", req)
+
+	restore := &pvpoolv1.PvPoolRestore{}
+	err := r.Get(ctx, req.NamespacedName, restore)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			r.Log.Info("PvPoolRestore resource not found. Ignoring since object must be deleted")
+			return doNotRequeue()
+		}
+		r.Log.Error(err, "Failed to get PvPoolRestore")
+		return requeueWithError(err)
+	}
+
+	if restore.Status.Phase == pvpoolv1.PvPoolRestorePhaseComplete {
+		return doNotRequeue()
+	}
+
+	restore.Status.Phase = pvpoolv1.PvPoolRestorePhaseBinding
+
+	bound, err := r.makeVolumesClaimable(ctx, restore)
+	restore.Status.BoundVolumes = bound
+	if err != nil {
+		r.Log.Error(err, "Failed to make retained volumes claimable")
+		if statusErr := r.Status().Update(ctx, restore); statusErr != nil {
+			r.Log.Error(statusErr, "Failed to update PvPoolRestore status")
+		}
+		return requeueWithError(err)
+	}
+
+	if bound < int32(len(restore.Spec.RetainedVolumes)) {
+		if err := r.Status().Update(ctx, restore); err != nil {
+			r.Log.Error(err, "Failed to update PvPoolRestore status")
+			return requeueWithError(err)
+		}
+		return requeueAfterSeconds(3)
+	}
+
+	if err := r.ensureRestoredPvPool(ctx, restore); err != nil {
+		r.Log.Error(err, "Failed to create restored PvPool")
+		if statusErr := r.Status().Update(ctx, restore); statusErr != nil {
+			r.Log.Error(statusErr, "Failed to update PvPoolRestore status")
+		}
+		return requeueWithError(err)
+	}
+
+	restore.Status.Phase = pvpoolv1.PvPoolRestorePhaseComplete
+	if err := r.Status().Update(ctx, restore); err != nil {
+		r.Log.Error(err, "Failed to update PvPoolRestore status")
+		return requeueWithError(err)
+	}
+
+	return doNotRequeue()
+}
+
+// makeVolumesClaimable re-points the claimRef left over from the previous
+// PvPool on every retained PV at the specific PVC its ordinal will bind to
+// in the new StatefulSet (vol-<new-sts>-<ordinal>), pre-binding it rather
+// than merely freeing it, so a retained PV always rebinds to the ordinal
+// RetainedVolume recorded it against instead of whichever PVC claims first.
+// It returns how many volumes are claimable so far.
+func (r *PvPoolRestoreReconciler) makeVolumesClaimable(ctx context.Context, restore *pvpoolv1.PvPoolRestore) (int32, error) {
+	bound := int32(0)
+	stsName := naming.ChildName(restore.Spec.PvPoolName, "-sts")
+
+	for _, rv := range restore.Spec.RetainedVolumes {
+		pv := &corev1.PersistentVolume{}
+		if err := r.Get(ctx, types.NamespacedName{Name: rv.PVName}, pv); err != nil {
+			if errors.IsNotFound(err) {
+				r.Log.Info("retained PV no longer exists, skipping", "pv", rv.PVName)
+				continue
+			}
+			return bound, err
+		}
+
+		pvcName := getVolumeClaimName(stsName, rv.Ordinal)
+		if pv.Spec.ClaimRef == nil || pv.Spec.ClaimRef.Name != pvcName || pv.Spec.ClaimRef.Namespace != restore.Namespace {
+			pv.Spec.ClaimRef = &corev1.ObjectReference{
+				Kind:      "PersistentVolumeClaim",
+				Namespace: restore.Namespace,
+				Name:      pvcName,
+			}
+			if err := r.Update(ctx, pv); err != nil {
+				return bound, err
+			}
+		}
+
+		bound++
+	}
+
+	return bound, nil
+}
+
+// ensureRestoredPvPool creates the PvPool described by the restore's
+// template, sized to exactly the number of volumes it just freed up.
+func (r *PvPoolRestoreReconciler) ensureRestoredPvPool(ctx context.Context, restore *pvpoolv1.PvPoolRestore) error {
+	existing := &pvpoolv1.PvPool{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: restore.Namespace, Name: restore.Spec.PvPoolName}, existing)
+	if err == nil {
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return err
+	}
+
+	pvPool := &pvpoolv1.PvPool{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      restore.Spec.PvPoolName,
+			Namespace: restore.Namespace,
+		},
+		Spec: *restore.Spec.PvPoolTemplate.DeepCopy(),
+	}
+	pvPool.Spec.NumPVs = int32(len(restore.Spec.RetainedVolumes))
+
+	// deliberately no owner reference: a restore is a one-shot action, and
+	// owning the pool would mean deleting this PvPoolRestore cascades to
+	// deleting the PvPool it just rebuilt - for a pool defaulting to
+	// DeletePolicy: WipeAndDelete, that scrubs and destroys the very data
+	// the restore recovered. Leave the restored pool to live (and be
+	// deleted) independently of the restore that created it.
+	return r.Create(ctx, pvPool)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PvPoolRestoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// no Owns(&pvpoolv1.PvPool{}): the restored PvPool is deliberately left
+	// unowned (see ensureRestoredPvPool), so there is no owner reference for
+	// such a watch to map back through.
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&pvpoolv1.PvPoolRestore{}).
+		Complete(r)
+}
@@ -0,0 +1,486 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	pvpoolv1 "github.com/noobaa/pv-pool-operator/api/v1"
+)
+
+// finalizePvPool runs the action called for by Spec.DeletePolicy on every
+// PVC/PV still bound to the pool before letting the PvPool's deletion
+// through, so owner-ref GC never races ahead of a wipe or a retain.
+func (r *PvPoolReconciler) finalizePvPool(ctx context.Context, pvp *pvpoolv1.PvPool) (ctrl.Result, error) {
+	if !containsString(pvp.Finalizers, pvPoolFinalizer) {
+		return doNotRequeue()
+	}
+
+	stsName := r.getPvPoolStatefulsetName(pvp)
+	sts := &appsv1.StatefulSet{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: pvp.Namespace, Name: stsName}, sts)
+	if err != nil && !errors.IsNotFound(err) {
+		r.Log.Error(err, "failed to get statefulset while finalizing PvPool")
+		return requeueWithError(err)
+	}
+
+	if err == nil && pvp.Spec.DeletePolicy != pvpoolv1.DeletePolicyOrphan {
+		// scale the StatefulSet to 0 and wait for its pods to actually
+		// terminate before touching any PVC/PV: a scrubber pod (or a PV
+		// we're about to retain) must never contend with a live workload pod
+		// for the same ReadWriteOnce volume. Orphan leaves the StatefulSet
+		// running as it is.
+		if sts.Spec.Replicas == nil || *sts.Spec.Replicas != 0 {
+			zero := int32(0)
+			sts.Spec.Replicas = &zero
+			if err := r.Update(ctx, sts); err != nil {
+				r.Log.Error(err, "failed to scale statefulset to 0 while finalizing PvPool")
+				return requeueWithError(err)
+			}
+			r.Log.Info("scaling statefulset to 0 before finalizing PvPool", "statefulset name", stsName)
+			return requeueAfterSeconds(3)
+		}
+
+		podList := &corev1.PodList{}
+		if err := r.List(ctx, podList, client.InNamespace(pvp.Namespace), client.MatchingLabels(r.getPvPoolLabels(pvp))); err != nil {
+			r.Log.Error(err, "failed to list pods while finalizing PvPool")
+			return requeueWithError(err)
+		}
+		if len(podList.Items) > 0 {
+			r.Log.Info("waiting for statefulset pods to terminate before finalizing PvPool", "statefulset name", stsName)
+			return requeueAfterSeconds(3)
+		}
+	}
+
+	claims, err := r.listPvPoolVolumeClaims(ctx, pvp, stsName)
+	if err != nil {
+		r.Log.Error(err, "failed to list pvpool PVCs while finalizing PvPool")
+		return requeueWithError(err)
+	}
+
+	switch pvp.Spec.DeletePolicy {
+	case pvpoolv1.DeletePolicyRetain:
+		retained, err := r.retainAllVolumes(claims)
+		if err != nil {
+			r.Log.Error(err, "failed to retain pvpool volumes on delete")
+			return requeueWithError(err)
+		}
+		pvp.Status.RetainedVolumes = retained
+		if err := r.Status().Update(ctx, pvp); err != nil {
+			r.Log.Error(err, "failed to record retained volumes on PvPool status")
+			return requeueWithError(err)
+		}
+
+	case pvpoolv1.DeletePolicyOrphan:
+		if err := r.dropOwnedObjectsOwnerRefs(ctx, pvp); err != nil {
+			r.Log.Error(err, "failed to drop owner references while orphaning PvPool")
+			return requeueWithError(err)
+		}
+
+	default: // DeletePolicyWipeAndDelete
+		jobs, err := r.reclaimAllOrphanedPVCs(pvp, claims)
+		if err != nil {
+			r.Log.Error(err, "failed to scrub pvpool PVCs on delete")
+			return requeueWithError(err)
+		}
+		if !allDecommissionJobsDone(jobs, int32(len(claims))) {
+			r.Log.Info("still scrubbing PVCs before allowing PvPool deletion", "statefulset name", stsName)
+			return requeueAfterSeconds(3)
+		}
+	}
+
+	pvp.Finalizers = removeString(pvp.Finalizers, pvPoolFinalizer)
+	if err := r.Update(ctx, pvp); err != nil {
+		r.Log.Error(err, "failed to remove finalizer from PvPool")
+		return requeueWithError(err)
+	}
+
+	return doNotRequeue()
+}
+
+// listPvPoolVolumeClaims lists every PVC still left behind by the pool's
+// StatefulSet VolumeClaimTemplate, keyed by ordinal. Discovering them this
+// way (rather than ranging over [0, sts.Spec.Replicas)) makes every finalize
+// step idempotent for free: a PVC already reclaimed on a prior reconcile
+// simply no longer appears.
+func (r *PvPoolReconciler) listPvPoolVolumeClaims(ctx context.Context, pvp *pvpoolv1.PvPool, stsName string) (map[int32]corev1.PersistentVolumeClaim, error) {
+	pvcList := &corev1.PersistentVolumeClaimList{}
+	if err := r.List(ctx, pvcList, client.InNamespace(pvp.Namespace)); err != nil {
+		return nil, err
+	}
+
+	prefix := fmt.Sprintf("vol-%s-", stsName)
+	claims := make(map[int32]corev1.PersistentVolumeClaim)
+	for _, pvc := range pvcList.Items {
+		if !strings.HasPrefix(pvc.Name, prefix) {
+			continue
+		}
+		ordinal, err := strconv.Atoi(strings.TrimPrefix(pvc.Name, prefix))
+		if err != nil {
+			continue
+		}
+		claims[int32(ordinal)] = pvc
+	}
+	return claims, nil
+}
+
+// retainAllVolumes patches every PV bound to claims to
+// PersistentVolumeReclaimPolicy: Retain and records the metadata
+// PvPoolRestore needs to rebuild a pool bound to them.
+func (r *PvPoolReconciler) retainAllVolumes(claims map[int32]corev1.PersistentVolumeClaim) ([]pvpoolv1.RetainedVolume, error) {
+	retained := make([]pvpoolv1.RetainedVolume, 0, len(claims))
+
+	for _, ordinal := range sortedOrdinals(claims) {
+		pvc := claims[ordinal]
+		if pvc.Spec.VolumeName == "" {
+			continue
+		}
+
+		pv := &corev1.PersistentVolume{}
+		if err := r.Get(context.TODO(), types.NamespacedName{Name: pvc.Spec.VolumeName}, pv); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return retained, err
+		}
+
+		pv.Spec.PersistentVolumeReclaimPolicy = corev1.PersistentVolumeReclaimRetain
+		if err := r.Update(context.TODO(), pv); err != nil {
+			return retained, err
+		}
+
+		retained = append(retained, pvpoolv1.RetainedVolume{
+			Ordinal:          ordinal,
+			PVName:           pv.Name,
+			StorageClassName: pv.Spec.StorageClassName,
+			Capacity:         pv.Spec.Capacity[corev1.ResourceStorage],
+			AccessModes:      pv.Spec.AccessModes,
+			NodeAffinity:     pv.Spec.NodeAffinity,
+		})
+	}
+
+	return retained, nil
+}
+
+// sortedOrdinals returns the keys of claims in ascending order, so iteration
+// (and the resulting RetainedVolume/DecommissionJob order) is deterministic.
+func sortedOrdinals(claims map[int32]corev1.PersistentVolumeClaim) []int32 {
+	ordinals := make([]int32, 0, len(claims))
+	for ordinal := range claims {
+		ordinals = append(ordinals, ordinal)
+	}
+	sort.Slice(ordinals, func(i, j int) bool { return ordinals[i] < ordinals[j] })
+	return ordinals
+}
+
+// sortedOrdinalSet returns the members of set in ascending order, so
+// iteration (and the resulting DecommissionJob order) is deterministic.
+func sortedOrdinalSet(set map[int32]struct{}) []int32 {
+	ordinals := make([]int32, 0, len(set))
+	for ordinal := range set {
+		ordinals = append(ordinals, ordinal)
+	}
+	sort.Slice(ordinals, func(i, j int) bool { return ordinals[i] < ordinals[j] })
+	return ordinals
+}
+
+// dropOwnedObjectsOwnerRefs removes the PvPool's owner reference from its
+// Service and StatefulSet, so deleting the PvPool leaves them (and their
+// PVCs/PVs) running untouched.
+func (r *PvPoolReconciler) dropOwnedObjectsOwnerRefs(ctx context.Context, pvp *pvpoolv1.PvPool) error {
+	srv := &corev1.Service{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: pvp.Namespace, Name: r.getPvPoolServiceName(pvp)}, srv); err == nil {
+		srv.OwnerReferences = nil
+		if err := r.Update(ctx, srv); err != nil {
+			return err
+		}
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+
+	sts := &appsv1.StatefulSet{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: pvp.Namespace, Name: r.getPvPoolStatefulsetName(pvp)}, sts); err == nil {
+		sts.OwnerReferences = nil
+		if err := r.Update(ctx, sts); err != nil {
+			return err
+		}
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
+
+// startedAtFor returns the StartedAt already recorded for ordinal in the
+// PvPool's last persisted status, or the current time if this is the first
+// time we've seen it, so resuming an in-progress job across reconciles never
+// resets how long it has been running.
+func startedAtFor(pvp *pvpoolv1.PvPool, ordinal int32) v1.Time {
+	if job := jobForOrdinal(pvp.Status.DecommissionJobs, ordinal); job != nil {
+		return job.StartedAt
+	}
+	return v1.Now()
+}
+
+// allDecommissionJobsDone reports whether every one of the expected orphaned
+// PVCs has finished being scrubbed and removed.
+func allDecommissionJobsDone(jobs []pvpoolv1.DecommissionJob, expected int32) bool {
+	if int32(len(jobs)) < expected {
+		return false
+	}
+	for _, job := range jobs {
+		if job.Phase != pvpoolv1.DecommissionJobPhaseDone {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(slice []string, s string) bool {
+	for _, item := range slice {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func removeString(slice []string, s string) []string {
+	result := make([]string, 0, len(slice))
+	for _, item := range slice {
+		if item != s {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// pvPoolFinalizer holds the PvPool CR's deletion until finalizePvPool has
+// carried out Spec.DeletePolicy, so owner-ref GC never races ahead of a
+// wipe or a retain.
+const pvPoolFinalizer = "pvpool.noobaa.com/finalizer"
+
+// getVolumeClaimName returns the conventional name of the PVC created by the
+// StatefulSet's VolumeClaimTemplates for the given ordinal.
+func getVolumeClaimName(stsName string, ordinal int32) string {
+	return fmt.Sprintf("vol-%s-%d", stsName, ordinal)
+}
+
+// getScrubberPodName returns the conventional name of the scrubber Pod that
+// wipes the PVC at the given ordinal.
+func getScrubberPodName(stsName string, ordinal int32) string {
+	return fmt.Sprintf("%s-scrub-%d", stsName, ordinal)
+}
+
+// newScrubberPod builds the short-lived Pod that mounts an orphaned PVC and
+// wipes its contents, modeled after the Thanos Receive Controller hashring
+// scrubber: one Pod with a single volumeMount, a shell loop that removes
+// everything under the mount, and restartPolicy: OnFailure so the kubelet
+// retries a failed wipe without the controller having to recreate the Pod.
+func (r *PvPoolReconciler) newScrubberPod(pvp *pvpoolv1.PvPool, pvcName string, ordinal int32) *corev1.Pod {
+	image := pvp.Spec.ScrubberImage
+	if image == "" {
+		image = pvp.Spec.Image
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      getScrubberPodName(r.getPvPoolStatefulsetName(pvp), ordinal),
+			Namespace: pvp.Namespace,
+			// not the pool's selector labels: a scrubber must never be listed,
+			// selected or queried as one of the pool's storage-agent pods
+			Labels: map[string]string{"pv-pool-scrubber": r.getPvPoolLabels(pvp)[labelPoolHash]},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyOnFailure,
+			Containers: []corev1.Container{
+				{
+					Name:    "scrubber",
+					Image:   image,
+					Command: []string{"sh", "-c", "for d in /mnt/*; do rm -rf $d/*; done"},
+					VolumeMounts: []corev1.VolumeMount{
+						{
+							Name:      "vol",
+							MountPath: "/mnt/vol",
+						},
+					},
+				},
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name: "vol",
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+							ClaimName: pvcName,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	ctrl.SetControllerReference(pvp, pod, r.Scheme)
+
+	return pod
+}
+
+// ensureScrubberPod creates the scrubber Pod for the given PVC if it does not
+// already exist, and returns the Pod as currently stored. Re-running this against an
+// already-created Pod is a no-op, which is what makes the cleanup idempotent
+// across reconciles.
+func (r *PvPoolReconciler) ensureScrubberPod(pvp *pvpoolv1.PvPool, pvcName string, ordinal int32) (*corev1.Pod, error) {
+	pod := &corev1.Pod{}
+	podName := getScrubberPodName(r.getPvPoolStatefulsetName(pvp), ordinal)
+	err := r.Get(context.TODO(), types.NamespacedName{Namespace: pvp.Namespace, Name: podName}, pod)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+		pod = r.newScrubberPod(pvp, pvcName, ordinal)
+		r.Log.Info("creating scrubber pod", "pod name", pod.Name, "pvc", pvcName)
+		if err := r.Create(context.TODO(), pod); err != nil {
+			return nil, err
+		}
+		return pod, nil
+	}
+	return pod, nil
+}
+
+// scrubberPhase maps a scrubber Pod's status onto a DecommissionJobPhase.
+func scrubberPhase(pod *corev1.Pod) pvpoolv1.DecommissionJobPhase {
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		return pvpoolv1.DecommissionJobPhaseSucceeded
+	case corev1.PodFailed:
+		return pvpoolv1.DecommissionJobPhaseFailed
+	case corev1.PodRunning:
+		return pvpoolv1.DecommissionJobPhaseScrubbing
+	default:
+		return pvpoolv1.DecommissionJobPhasePending
+	}
+}
+
+// reclaimOrphanedPVC runs (or resumes) the scrub of a single orphaned PVC and
+// deletes the PVC, and optionally its bound PV, once the scrub succeeds.
+// The resulting DecommissionJob is returned so the caller can persist
+// progress in PvPoolStatus for the next reconcile.
+func (r *PvPoolReconciler) reclaimOrphanedPVC(pvp *pvpoolv1.PvPool, ordinal int32) (*pvpoolv1.DecommissionJob, error) {
+	pvcName := getVolumeClaimName(r.getPvPoolStatefulsetName(pvp), ordinal)
+
+	pod, err := r.ensureScrubberPod(pvp, pvcName, ordinal)
+	if err != nil {
+		r.Log.Error(err, "failed to ensure scrubber pod", "pvc", pvcName)
+		return nil, err
+	}
+
+	phase := scrubberPhase(pod)
+	job := &pvpoolv1.DecommissionJob{
+		Ordinal:   ordinal,
+		PodName:   pod.Name,
+		Phase:     phase,
+		StartedAt: startedAtFor(pvp, ordinal),
+	}
+
+	if phase == pvpoolv1.DecommissionJobPhaseFailed {
+		// delete the failed scrubber pod so the next reconcile recreates it
+		if err := r.Delete(context.TODO(), pod); err != nil && !errors.IsNotFound(err) {
+			r.Log.Error(err, "failed to delete failed scrubber pod", "pod name", pod.Name)
+			return job, err
+		}
+		return job, nil
+	}
+
+	if phase != pvpoolv1.DecommissionJobPhaseSucceeded {
+		return job, nil
+	}
+
+	if err := r.deletePVCAndMaybePV(pvp, pvcName); err != nil {
+		r.Log.Error(err, "failed to delete scrubbed pvc", "pvc", pvcName)
+		return job, err
+	}
+
+	if err := r.Delete(context.TODO(), pod); err != nil && !errors.IsNotFound(err) {
+		r.Log.Error(err, "failed to delete scrubber pod", "pod name", pod.Name)
+		return job, err
+	}
+
+	job.Phase = pvpoolv1.DecommissionJobPhaseDone
+	return job, nil
+}
+
+// deletePVCAndMaybePV deletes the named PVC, and if the pool's reclaim policy
+// is Delete, also deletes the PV it was bound to.
+func (r *PvPoolReconciler) deletePVCAndMaybePV(pvp *pvpoolv1.PvPool, pvcName string) error {
+	pvc := &corev1.PersistentVolumeClaim{}
+	err := r.Get(context.TODO(), types.NamespacedName{Namespace: pvp.Namespace, Name: pvcName}, pvc)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	volumeName := pvc.Spec.VolumeName
+
+	if err := r.Delete(context.TODO(), pvc); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	if pvp.Spec.ReclaimPolicy == pvpoolv1.PVReclaimPolicyDelete && volumeName != "" {
+		pv := &corev1.PersistentVolume{}
+		err := r.Get(context.TODO(), types.NamespacedName{Name: volumeName}, pv)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		if err := r.Delete(context.TODO(), pv); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reclaimAllOrphanedPVCs scrubs and removes every PVC in claims, used when
+// the PvPool CR itself is being deleted.
+func (r *PvPoolReconciler) reclaimAllOrphanedPVCs(pvp *pvpoolv1.PvPool, claims map[int32]corev1.PersistentVolumeClaim) ([]pvpoolv1.DecommissionJob, error) {
+	jobs := make([]pvpoolv1.DecommissionJob, 0, len(claims))
+	for _, ordinal := range sortedOrdinals(claims) {
+		job, err := r.reclaimOrphanedPVC(pvp, ordinal)
+		if job != nil {
+			jobs = append(jobs, *job)
+		}
+		if err != nil {
+			return jobs, err
+		}
+	}
+	return jobs, nil
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"io/ioutil"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	pvpoolv1 "github.com/noobaa/pv-pool-operator/api/v1"
+	"github.com/noobaa/pv-pool-operator/pkg/storageagent"
+)
+
+// serviceAccountTokenPath is where Kubernetes projects the pod's own
+// ServiceAccount token, used to authenticate outgoing HTTP calls to the
+// storage agent.
+const serviceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// maxConcurrentAgentQueries bounds how many storage agents are queried in
+// parallel per reconcile, so one hung pod can no longer stall the whole
+// reconcile for (timeout * replica count).
+const maxConcurrentAgentQueries = 8
+
+// storageAgentClient builds the storageagent.Client for the transport
+// selected on the PvPool spec.
+func (r *PvPoolReconciler) storageAgentClient(pvp *pvpoolv1.PvPool) storageagent.Client {
+	tlsCfg, hasTLS := r.loadAgentTLSConfig(pvp)
+
+	if pvp.Spec.AgentProtocol == pvpoolv1.AgentProtocolGRPC {
+		if hasTLS {
+			return storageagent.NewGRPCClient(storageagent.GRPCCredentialsFromTLS(tlsCfg))
+		}
+		return storageagent.NewGRPCClient()
+	}
+
+	opts := []storageagent.HTTPClientOption{}
+	if hasTLS {
+		opts = append(opts, storageagent.WithTLS(tlsCfg))
+	}
+	if token, err := ioutil.ReadFile(serviceAccountTokenPath); err == nil {
+		opts = append(opts, storageagent.WithBearerToken(string(token)))
+	}
+	return storageagent.NewHTTPClient(opts...)
+}
+
+// loadAgentTLSConfig reads the CA/client cert/key out of the Secret named by
+// Spec.TLS.SecretRef, returning ok=false when TLS is not configured.
+func (r *PvPoolReconciler) loadAgentTLSConfig(pvp *pvpoolv1.PvPool) (storageagent.TLSConfig, bool) {
+	if pvp.Spec.TLS == nil || pvp.Spec.TLS.SecretRef.Name == "" {
+		return storageagent.TLSConfig{}, false
+	}
+
+	secret := &corev1.Secret{}
+	err := r.Get(context.TODO(), types.NamespacedName{Namespace: pvp.Namespace, Name: pvp.Spec.TLS.SecretRef.Name}, secret)
+	if err != nil {
+		r.Log.Error(err, "failed to load storage agent TLS secret", "secret name", pvp.Spec.TLS.SecretRef.Name)
+		return storageagent.TLSConfig{}, false
+	}
+
+	return storageagent.TLSConfig{
+		CACert:     secret.Data["ca.crt"],
+		ClientCert: secret.Data["tls.crt"],
+		ClientKey:  secret.Data["tls.key"],
+		ServerName: pvp.Name,
+	}, true
+}
+
+// podStatusResult pairs a pod with its queried agent status or error, so a
+// bounded worker pool can fan out status queries without blocking the
+// reconcile on any single hung pod.
+type podStatusResult struct {
+	pod    corev1.Pod
+	status *storageagent.AgentStatus
+	err    error
+}
+
+// queryPodsStatusConcurrently fans out Status() calls across up to
+// maxConcurrentAgentQueries workers and returns one result per pod, in the
+// same order as the input list.
+func (r *PvPoolReconciler) queryPodsStatusConcurrently(ctx context.Context, client storageagent.Client, list *corev1.PodList) []podStatusResult {
+	results := make([]podStatusResult, len(list.Items))
+	sem := make(chan struct{}, maxConcurrentAgentQueries)
+	done := make(chan struct{})
+
+	for i := range list.Items {
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() { <-sem; done <- struct{}{} }()
+			pod := list.Items[i]
+			status, err := client.Status(ctx, podFor(pod))
+			results[i] = podStatusResult{pod: pod, status: status, err: err}
+		}(i)
+	}
+
+	for range list.Items {
+		<-done
+	}
+
+	return results
+}
+
+// podFor adapts a corev1.Pod into the transport-agnostic storageagent.Pod.
+func podFor(pod corev1.Pod) storageagent.Pod {
+	return storageagent.Pod{
+		Name:      pod.Name,
+		Namespace: pod.Namespace,
+		Subdomain: pod.Spec.Subdomain,
+	}
+}
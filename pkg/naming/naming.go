@@ -0,0 +1,90 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package naming derives DNS-1123/label-safe child object names from a
+// PvPool's name, truncating and hashing the tail when the name itself (plus
+// a suffix) would exceed Kubernetes' 63 character limit for Service,
+// StatefulSet and label values, or contains characters label values reject.
+package naming
+
+import (
+	"hash/fnv"
+	"regexp"
+)
+
+// maxNameLength is the DNS-1123 subdomain/label limit Kubernetes enforces on
+// Service names, StatefulSet names and label values.
+const maxNameLength = 63
+
+// disallowedLabelChars matches any character a Kubernetes label value does
+// not allow (labels permit alphanumerics plus '-', '_' and '.', but unlike
+// object names they must still start/end alphanumeric; we conservatively
+// normalize the separators our suffixes rely on).
+var disallowedLabelChars = regexp.MustCompile(`[^a-zA-Z0-9-]`)
+
+// FNV32aHex returns the lowercase hex-encoded 32-bit FNV-1a hash of s, used
+// as the short deterministic suffix appended to truncated names.
+func FNV32aHex(s string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(s))
+	return hex(h.Sum32())
+}
+
+func hex(v uint32) string {
+	const digits = "0123456789abcdef"
+	b := make([]byte, 8)
+	for i := 7; i >= 0; i-- {
+		b[i] = digits[v&0xf]
+		v >>= 4
+	}
+	return string(b)
+}
+
+// ChildName returns a name for a child object derived from base with the
+// given suffix (e.g. "-srv", "-sts"), guaranteed to be a valid DNS-1123
+// name of at most 63 characters. When base+suffix already fits and contains
+// only characters Kubernetes allows in both object names and label values,
+// it is returned unchanged; otherwise the tail of base is replaced with a
+// short deterministic hash of the original base so the result stays stable
+// across reconciles.
+func ChildName(base, suffix string) string {
+	candidate := base + suffix
+	if len(candidate) <= maxNameLength && !disallowedLabelChars.MatchString(base) {
+		return candidate
+	}
+
+	hash := FNV32aHex(base)[:8]
+	// leave room for "-" + hash + suffix
+	maxBaseLen := maxNameLength - len(suffix) - len(hash) - 1
+	if maxBaseLen > len(base) {
+		maxBaseLen = len(base)
+	}
+	if maxBaseLen < 0 {
+		maxBaseLen = 0
+	}
+	sanitizedBase := disallowedLabelChars.ReplaceAllString(base, "-")
+	if len(sanitizedBase) > maxBaseLen {
+		sanitizedBase = sanitizedBase[:maxBaseLen]
+	}
+	return sanitizedBase + "-" + hash + suffix
+}
+
+// LabelHash returns the short deterministic hash used as the `pv-pool-hash`
+// selector label value for a PvPool named name, so selectors stay valid and
+// stable even when the PvPool name itself is not a valid label value.
+func LabelHash(name string) string {
+	return FNV32aHex(name)
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package storageagent abstracts how the operator talks to the storage-agent
+// sidecar running in every PV pool pod, so the transport (plain HTTP polling
+// or gRPC streaming) can be swapped without touching the reconciler.
+package storageagent
+
+import (
+	"context"
+)
+
+// AgentStatus is the status reported by a storage agent, transport-agnostic.
+type AgentStatus struct {
+	Name  string
+	Total int64
+	Used  int64
+	State string
+}
+
+// Pod identifies the storage-agent instance a Client call targets.
+type Pod struct {
+	Name      string
+	Namespace string
+	// Subdomain is the headless Service name the pod is addressed through
+	// (set by the StatefulSet controller to its own ServiceName).
+	Subdomain string
+}
+
+// Client talks to a single storage agent instance. Implementations must be
+// safe for concurrent use, since the reconciler queries every pod in the
+// pool concurrently.
+type Client interface {
+	// Status fetches the current status reported by the storage agent.
+	Status(ctx context.Context, pod Pod) (*AgentStatus, error)
+	// Decommission tells the storage agent to start draining.
+	Decommission(ctx context.Context, pod Pod) error
+}
+
+// TLSConfig configures mTLS for the HTTP and gRPC transports, sourced from a
+// PvPool's Spec.TLS.SecretRef.
+type TLSConfig struct {
+	CACert     []byte
+	ClientCert []byte
+	ClientKey  []byte
+	ServerName string
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storageagent
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/noobaa/pv-pool-operator/pkg/storageagent/pb"
+)
+
+const grpcPort = 8443
+
+// GRPCClient implements Client over the StorageAgent gRPC service, caching
+// one connection per pod so repeated reconciles don't redial.
+type GRPCClient struct {
+	dialOpts []grpc.DialOption
+
+	mu    sync.Mutex
+	conns map[string]*grpc.ClientConn
+}
+
+// NewGRPCClient builds a gRPC-backed storage agent client. Pass the option
+// from GRPCCredentialsFromTLS to dial over mTLS; otherwise connections are insecure, which is only
+// appropriate for trusted in-cluster traffic.
+func NewGRPCClient(opts ...grpc.DialOption) *GRPCClient {
+	if len(opts) == 0 {
+		opts = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	}
+	return &GRPCClient{
+		dialOpts: opts,
+		conns:    make(map[string]*grpc.ClientConn),
+	}
+}
+
+// GRPCCredentialsFromTLS builds the grpc.DialOption for the given mTLS config.
+// When the client key pair cannot be parsed it returns insecure credentials.
+func GRPCCredentialsFromTLS(cfg TLSConfig) grpc.DialOption {
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(cfg.CACert)
+	cert, err := tls.X509KeyPair(cfg.ClientCert, cfg.ClientKey)
+	if err != nil {
+		return grpc.WithTransportCredentials(insecure.NewCredentials())
+	}
+	return grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+		RootCAs:      pool,
+		Certificates: []tls.Certificate{cert},
+		ServerName:   cfg.ServerName,
+	}))
+}
+
+func (c *GRPCClient) connFor(pod Pod) (*grpc.ClientConn, error) {
+	addr := fmt.Sprintf("%s.%s.%s.svc:%d", pod.Name, pod.Subdomain, pod.Namespace, grpcPort)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if conn, ok := c.conns[addr]; ok {
+		return conn, nil
+	}
+
+	conn, err := grpc.Dial(addr, c.dialOpts...)
+	if err != nil {
+		return nil, err
+	}
+	c.conns[addr] = conn
+	return conn, nil
+}
+
+// Status implements Client by taking the first status reported on the
+// server-streamed Status rpc.
+func (c *GRPCClient) Status(ctx context.Context, pod Pod) (*AgentStatus, error) {
+	conn, err := c.connFor(pod)
+	if err != nil {
+		return nil, err
+	}
+
+	// the ClientConn is cached and reused across reconciles, so the stream
+	// must be explicitly cancelled once we have what we need - otherwise it
+	// is left open server-side forever, since callers here pass in an
+	// uncancelled context.TODO().
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := pb.NewStorageAgentClient(conn).Status(streamCtx, &pb.StatusRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := stream.Recv()
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if res == nil {
+		return nil, fmt.Errorf("storage agent closed the status stream without reporting a status")
+	}
+
+	return &AgentStatus{
+		Name:  res.Name,
+		Total: res.Total,
+		Used:  res.Used,
+		State: res.State,
+	}, nil
+}
+
+// Decommission implements Client.
+func (c *GRPCClient) Decommission(ctx context.Context, pod Pod) error {
+	conn, err := c.connFor(pod)
+	if err != nil {
+		return err
+	}
+
+	_, err = pb.NewStorageAgentClient(conn).Decommission(ctx, &pb.DecommissionRequest{})
+	return err
+}
+
+// Close tears down every cached connection.
+func (c *GRPCClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var firstErr error
+	for addr, conn := range c.conns {
+		if err := conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(c.conns, addr)
+	}
+	return firstErr
+}